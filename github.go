@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Repo describes a single GitHub repository (or gist, normalized to the
+// same shape) that gitexhume can clone and scan.
+type Repo struct {
+	Name     string `json:"name"`
+	Size     int    `json:"size"`
+	Fork     bool   `json:"fork"`
+	CloneURL string `json:"clone_url"`
+}
+
+// gist is the subset of the GitHub gists API response we care about.
+type gist struct {
+	ID         string `json:"id"`
+	GitPullURL string `json:"git_pull_url"`
+	Public     bool   `json:"public"`
+}
+
+var linkNextRe = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// fetchRepos retrieves all public GitHub repositories for the given username
+// using the GitHub REST API and returns them as a slice of Repo. If token is
+// non-empty it is sent as an Authorization header, which raises the rate
+// limit and allows private repos to be listed. The program returns an error
+// on network, API, or decoding errors.
+func fetchRepos(username, token string) ([]Repo, error) {
+	userUrl := fmt.Sprintf("https://api.github.com/users/%s/repos?per_page=100", username)
+	return fetchAllRepos(userUrl, token)
+}
+
+// fetchOrgRepos retrieves all repositories belonging to the given GitHub
+// organization, following pagination the same way fetchRepos does.
+func fetchOrgRepos(org, token string) ([]Repo, error) {
+	orgUrl := fmt.Sprintf("https://api.github.com/orgs/%s/repos?per_page=100", org)
+	return fetchAllRepos(orgUrl, token)
+}
+
+// fetchGistRepos retrieves all gists owned by the given username and
+// presents them as Repo values so they can flow through the same
+// clone/scan pipeline as ordinary repositories.
+func fetchGistRepos(username, token string) ([]Repo, error) {
+	gistUrl := fmt.Sprintf("https://api.github.com/users/%s/gists?per_page=100", username)
+
+	var gists []gist
+	for gistUrl != "" {
+		resp, err := githubGet(gistUrl, token)
+		if err != nil {
+			return nil, err
+		}
+
+		var page []gist
+		err = decodeAndClose(resp, &page)
+		if err != nil {
+			return nil, err
+		}
+		gists = append(gists, page...)
+
+		gistUrl = nextPageURL(resp)
+	}
+
+	repos := make([]Repo, 0, len(gists))
+	for _, g := range gists {
+		repos = append(repos, Repo{Name: g.ID, CloneURL: g.GitPullURL})
+	}
+
+	return repos, nil
+}
+
+// filterForks drops forked repositories from repos unless includeForks is
+// true. Forks almost always duplicate hits already found in the upstream
+// repo, so they are excluded by default.
+func filterForks(repos []Repo, includeForks bool) []Repo {
+	if includeForks {
+		return repos
+	}
+
+	filtered := make([]Repo, 0, len(repos))
+	for _, r := range repos {
+		if !r.Fork {
+			filtered = append(filtered, r)
+		}
+	}
+
+	return filtered
+}
+
+// fetchAllRepos performs the GET request at url and follows the Link:
+// rel="next" header to walk through every page of results, honoring
+// rate-limit headers along the way.
+func fetchAllRepos(url, token string) ([]Repo, error) {
+	var repos []Repo
+
+	for url != "" {
+		resp, err := githubGet(url, token)
+		if err != nil {
+			return nil, err
+		}
+
+		var page []Repo
+		if err := decodeAndClose(resp, &page); err != nil {
+			return nil, err
+		}
+		repos = append(repos, page...)
+
+		url = nextPageURL(resp)
+	}
+
+	return repos, nil
+}
+
+// githubGet issues an authenticated GET request to the GitHub API, retrying
+// once after waiting out the rate limit if the server reports it is
+// exhausted.
+func githubGet(url, token string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "token "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if waitForRateLimit(resp) {
+		resp.Body.Close()
+		return githubGet(url, token)
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("Github API error (%d): %s", resp.StatusCode, body)
+	}
+
+	return resp, nil
+}
+
+// waitForRateLimit inspects resp for GitHub's rate-limit headers and, if the
+// request was throttled, sleeps until the limit resets and reports true so
+// the caller can retry. It reports false if no wait was necessary.
+func waitForRateLimit(resp *http.Response) bool {
+	if resp.StatusCode != 403 && resp.StatusCode != 429 {
+		return false
+	}
+
+	// GitHub's secondary-rate-limit / abuse-detection response sets
+	// Retry-After without necessarily zeroing (or even including)
+	// X-RateLimit-Remaining, so this must be checked independently of it.
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if secs, err := strconv.Atoi(retryAfter); err == nil {
+			time.Sleep(time.Duration(secs) * time.Second)
+			return true
+		}
+	}
+
+	if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining != "0" {
+		return false
+	}
+
+	reset := resp.Header.Get("X-RateLimit-Reset")
+	resetUnix, err := strconv.ParseInt(reset, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	wait := time.Until(time.Unix(resetUnix, 0))
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+
+	return true
+}
+
+// nextPageURL extracts the "next" URL from a GitHub Link header, or the
+// empty string if there is no further page.
+func nextPageURL(resp *http.Response) string {
+	link := resp.Header.Get("Link")
+	if link == "" {
+		return ""
+	}
+
+	for _, part := range strings.Split(link, ",") {
+		if m := linkNextRe.FindStringSubmatch(part); m != nil {
+			return m[1]
+		}
+	}
+
+	return ""
+}
+
+// decodeAndClose decodes resp's JSON body into v and closes the body.
+func decodeAndClose(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
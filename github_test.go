@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNextPageURL(t *testing.T) {
+	cases := []struct {
+		name string
+		link string
+		want string
+	}{
+		{"no link header", "", ""},
+		{
+			"next and last",
+			`<https://api.github.com/repos?page=2>; rel="next", <https://api.github.com/repos?page=5>; rel="last"`,
+			"https://api.github.com/repos?page=2",
+		},
+		{
+			"last page has no next",
+			`<https://api.github.com/repos?page=1>; rel="prev", <https://api.github.com/repos?page=1>; rel="first"`,
+			"",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if c.link != "" {
+				resp.Header.Set("Link", c.link)
+			}
+
+			if got := nextPageURL(resp); got != c.want {
+				t.Errorf("nextPageURL() = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestFilterForks(t *testing.T) {
+	repos := []Repo{
+		{Name: "upstream", Fork: false},
+		{Name: "a-fork", Fork: true},
+	}
+
+	if got := filterForks(repos, true); len(got) != 2 {
+		t.Errorf("includeForks=true: got %d repos, want 2", len(got))
+	}
+
+	got := filterForks(repos, false)
+	if len(got) != 1 || got[0].Name != "upstream" {
+		t.Errorf("includeForks=false: got %v, want only upstream", got)
+	}
+}
@@ -1,14 +1,11 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
+    "runtime"
     "strings"
-    "os/exec"
 )
 
 
@@ -18,6 +15,12 @@ const banner = `
 ░▀▀▀░▀▀▀░░▀░░▀▀▀░▀░▀░▀░▀░▀▀▀░▀░▀░▀▀▀`
 const version = `1.0`
 
+// DefaultWordlist is the wordlist file used when -w is not given.
+const DefaultWordlist = "wordlist.txt"
+
+// OutputDir is the directory repositories are cloned into in complete mode.
+var OutputDir = "repos"
+
 
 // readWordlist loads a wordlist file from disk and returns all
 // keywords as a slice of strings.
@@ -32,32 +35,6 @@ func readWordlist(wordlist string) ([]string, error) {
 }
 
 
-// fetchRepos retrieves all public GitHub repositories for the given username
-// using the GitHub REST API and returns them as a slice of Repo.
-// The program returns an error on network, API, or decoding errors.
-func fetchRepos(username string) ([]Repo, error) {
-    userUrl := fmt.Sprintf("https://api.github.com/users/%s/repos?per_page=100", username)
-
-    resp, err := http.Get(userUrl)
-    if err != nil {
-        return nil, err
-    }
-    defer resp.Body.Close()
-
-    if resp.StatusCode != 200 {
-        body, _ := io.ReadAll(resp.Body)
-        return nil, fmt.Errorf("Github API error (%d): %s", resp.StatusCode, body)
-    }
-
-    var repos []Repo
-    if err := json.NewDecoder(resp.Body).Decode(&repos); err != nil {
-        return nil, err
-    }
-
-    return repos, nil
-}
-
-
 // filterRepos restricts the given repository list to the names specified
 // in srepos. If srepos is empty, all repositories are returned.
 // The program returns an error if any requested repository does not exist.
@@ -89,94 +66,17 @@ func filterRepos(repos []Repo, srepos []string) ([]Repo, error) {
 // It returns the user's response as a string.
 func presentRepos(repos []Repo) string {
     var totalSize int = 0;
-    fmt.Println("Repositories:")
+    fmt.Fprintln(os.Stderr, "Repositories:")
     for _, r := range repos {
-        fmt.Printf(" - %s (%d KB)\n", r.Name, r.Size)
+        fmt.Fprintf(os.Stderr, " - %s (%d KB)\n", r.Name, r.Size)
         totalSize += r.Size
     }
 
     var Continue string
-    fmt.Printf("Total size is %v KB, continue? (Y/n): ", totalSize)
+    fmt.Fprintf(os.Stderr, "Total size is %v KB, continue? (Y/n): ", totalSize)
     fmt.Scanf("%s\n", &Continue)
-    
-    return Continue
-}
-
-
-// storeRepos clones all repositories into OutputDir using git.
-// Each repository is cloned into its own subdirectory.
-// The program returns an error if directory creation or cloning fails.
-func storeRepos(repos []Repo, OutputDir string, username string) {
-    if err := os.Mkdir(OutputDir, os.ModePerm); err != nil {
-        fmt.Printf("Error creating %s directory: %s\n", OutputDir, err)
-        os.Exit(1)
-    }
-
-    // Clone git repositories in OutputDir
-    for _, r := range repos {
-        repoURL := fmt.Sprintf("https://github.com/%s/%s.git", username, r.Name)
-        targetDir := fmt.Sprintf("%s/%s", OutputDir, r.Name)
-
-        cmd := exec.Command("git", "clone", repoURL, targetDir)
-        cmd.Stdout = nil
-        cmd.Stderr = nil
-
-        if err := cmd.Run(); err != nil {
-            fmt.Printf("Error cloning %s: %v\n", r.Name, err)
-            os.Exit(1)
-        }
-    }
-
-    fmt.Printf("\033[32mAll %d repositories have been cloned successfully\033[0m\n", len(repos))
-}
 
-
-// searchRepos scans the full commit history of each repository for the
-// configured keywords using git grep. Matching lines are printed once
-// per file and content combination to avoid duplicates.
-func searchRepos(repos []Repo, OutputDir string, words []string) {
-    for _, r := range repos {
-        repoDir := fmt.Sprintf("%s/%s", OutputDir, r.Name)
-        pattern := strings.Join(words, "|")
-
-        cmd := exec.Command(
-            "sh", "-c",
-            fmt.Sprintf(
-                "cd %s && git grep -n --color=always -E '%s' $(git rev-list --all)",
-                repoDir, pattern,
-            ),
-        )
-
-        out, err := cmd.Output()
-        if err != nil {
-            continue // no matches
-        }
-
-        seen := make(map[string]bool) // reset per repo
-        lines := strings.Split(strings.TrimSpace(string(out)), "\n")
-        for _, line := range lines {
-            // commit:file:line:content
-            parts := strings.SplitN(line, ":", 4)
-            if len(parts) < 4 {
-                continue
-            }
-
-            commit := parts[0][:11]
-            file := parts[1]
-            lineNr := parts[2]
-            content := parts[3]
-
-            // dedup key: same file + same content
-            key := file + "|" + content
-            if seen[key] {
-                continue
-            }
-            seen[key] = true
-
-            fmt.Printf("[%s] %s %s:%s\t", r.Name, commit, file, lineNr)
-            fmt.Printf("  %s\n", content)
-        }
-    }
+    return Continue
 }
 
 
@@ -187,17 +87,37 @@ func main() {
     var wFlag = flag.String("w", DefaultWordlist, "Wordlist file")
     var sFlag = flag.String("s", "", "Scan existing directory (skip cloning)")
     var vFlag = flag.Bool("version", false, "Print version and exit")
+    var tokenFlag = flag.String("token", "", "GitHub token (falls back to GITHUB_TOKEN env var)")
+    var orgFlag = flag.String("org", "", "Scan all repositories of a GitHub organization instead of a user")
+    var gistFlag = flag.Bool("gist", false, "Scan the gists owned by -u instead of their repositories")
+    var forksFlag = flag.Bool("forks", false, "Include forked repositories (forks usually duplicate hits)")
+    var jobsFlag = flag.Int("jobs", runtime.NumCPU(), "Number of concurrent clone/scan workers")
+    var rulesFlag = flag.String("rules", "", "Gitleaks-style TOML/YAML rule file (defaults to the built-in ruleset)")
+    var formatFlag = flag.String("format", "text", "Output format: text, json, or sarif")
+    var fullRescanFlag = flag.Bool("full-rescan", false, "Ignore the on-disk snapshot and rescan all history")
     flag.Parse()
 
+    token := *tokenFlag
+    if token == "" {
+        token = os.Getenv("GITHUB_TOKEN")
+    }
+
     // Validate and handle flags:
     // Display version
     if *vFlag {
         fmt.Printf("%s\nVersion: %s\n", banner, version)
         os.Exit(0)
     }
-    // -u is required unless -s is specified
-    if *sFlag == "" && *uFlag == "" {
-        fmt.Println("Error: -u is required unless -s is specified")
+    // -u is required unless -s or -org is specified
+    if *sFlag == "" && *uFlag == "" && *orgFlag == "" {
+        fmt.Println("Error: -u is required unless -s or -org is specified")
+        flag.Usage()
+        os.Exit(1)
+    }
+    // -jobs must spawn at least one worker, or the clone/scan pipeline's
+    // unbuffered channels block forever with nothing to read them
+    if *jobsFlag < 1 {
+        fmt.Println("Error: -jobs must be at least 1")
         flag.Usage()
         os.Exit(1)
     }
@@ -212,13 +132,33 @@ func main() {
         fmt.Fprintf(os.Stderr, "wordlist error: %v\n", err)
         os.Exit(1)
     }
-    
+    // Load secret-detection rules, falling back to the built-in ruleset
+    rules := defaultRules()
+    if *rulesFlag != "" {
+        rules, err = loadRules(*rulesFlag)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "rules error: %v\n", err)
+            os.Exit(1)
+        }
+    } else if err := rules.compile(); err != nil {
+        fmt.Fprintf(os.Stderr, "rules error: %v\n", err)
+        os.Exit(1)
+    }
+    reporter, err := newReporter(*formatFlag)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "format error: %v\n", err)
+        os.Exit(1)
+    }
 
-    fmt.Printf("%v\nVersion: %v\n\n", banner, version)
+
+    // Banner, progress, and prompts are UI chrome, not scan results, so they
+    // go to stderr: stdout must stay clean structured output in -format
+    // json/sarif, and plain text output otherwise.
+    fmt.Fprintf(os.Stderr, "%v\nVersion: %v\n\n", banner, version)
 
     // SCAN-ONLY MODE
     if *sFlag != "" {
-        fmt.Printf("Scanning existing directory: %s\n", *sFlag)
+        fmt.Fprintf(os.Stderr, "Scanning existing directory: %s\n", *sFlag)
 
         entries, err := os.ReadDir(*sFlag)
         if err != nil {
@@ -233,17 +173,34 @@ func main() {
             }
         }
 
-        searchRepos(repos, *sFlag, words)
+        snap, err := loadSnapshot(*sFlag)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "snapshot error: %v\n", err)
+            os.Exit(1)
+        }
+
+        searchRepos(repos, *sFlag, words, rules, reporter, snap, *fullRescanFlag, *jobsFlag)
+        reporter.Flush()
         os.Exit(0)
     }
 
     // COMPLETE MODE (fetch, clone, scan)
-    repos, err := fetchRepos(*uFlag)
+    var repos []Repo
+    switch {
+    case *orgFlag != "":
+        repos, err = fetchOrgRepos(*orgFlag, token)
+    case *gistFlag:
+        repos, err = fetchGistRepos(*uFlag, token)
+    default:
+        repos, err = fetchRepos(*uFlag, token)
+    }
     if err != nil {
         fmt.Fprintf(os.Stderr, "fetch error: %v\n", err)
         os.Exit(1)
     }
 
+    repos = filterForks(repos, *forksFlag)
+
     repos, err = filterRepos(repos, srepos)
     if err != nil {
         fmt.Fprintf(os.Stderr, "filter error: %v\n", err)
@@ -253,11 +210,17 @@ func main() {
     Continue := presentRepos(repos)
 
     if Continue == "Y" || Continue == "y" {
-        fmt.Println("Continuing...")
-        storeRepos(repos, OutputDir, *uFlag)
-        searchRepos(repos, OutputDir, words)
+        fmt.Fprintln(os.Stderr, "Continuing...")
+        snap, err := loadSnapshot(OutputDir)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "snapshot error: %v\n", err)
+            os.Exit(1)
+        }
+
+        storeAndScanRepos(repos, OutputDir, *uFlag, words, rules, reporter, snap, *fullRescanFlag, *jobsFlag)
+        reporter.Flush()
     } else {
-        fmt.Println("Not Continuing...")
+        fmt.Fprintln(os.Stderr, "Not Continuing...")
     }
 
     os.Exit(0)
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Finding is a single match, whether from the plain wordlist or a
+// secret-detection rule, recorded with enough context to re-locate it in
+// the repository's history.
+type Finding struct {
+	Repo          string    `json:"repo"`
+	Commit        string    `json:"commit"`
+	Author        string    `json:"author"`
+	Date          time.Time `json:"date"`
+	File          string    `json:"file"`
+	Line          int       `json:"line"`
+	Rule          string    `json:"rule"`
+	Match         string    `json:"match"`
+	SecretPreview string    `json:"secret_preview"`
+	BlobHash      string    `json:"-"`
+}
+
+// Reporter receives findings as they're scanned and, for formats that need
+// a whole-run view (SARIF), renders the final report once scanning
+// completes.
+type Reporter interface {
+	Report(f Finding)
+	Flush()
+}
+
+// newReporter builds the Reporter for the given -format value.
+func newReporter(format string) (Reporter, error) {
+	switch format {
+	case "", "text":
+		return &textReporter{}, nil
+	case "json":
+		return &jsonReporter{}, nil
+	case "sarif":
+		return &sarifReporter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q (want text, json, or sarif)", format)
+	}
+}
+
+// textReporter reproduces gitexhume's original colored, human-readable
+// output. Findings are printed as they arrive; Flush is a no-op.
+type textReporter struct {
+	mu sync.Mutex
+}
+
+func (t *textReporter) Report(f Finding) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Printf("[%s] %s %s:%d\t  %s\n", f.Repo, f.Rule, f.File, f.Line, f.Match)
+}
+
+func (t *textReporter) Flush() {}
+
+// jsonReporter emits one JSON object per finding (JSON Lines) as soon as
+// it's scanned, so output can be streamed into a consumer without waiting
+// for the whole run to finish.
+type jsonReporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (j *jsonReporter) Report(f Finding) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.enc == nil {
+		j.enc = json.NewEncoder(os.Stdout)
+	}
+	j.enc.Encode(f)
+}
+
+func (j *jsonReporter) Flush() {}
+
+// sarifReporter buffers every finding and, on Flush, renders a single
+// SARIF 2.1.0 report so the output can be ingested by GitHub's Advanced
+// Security tab or any other SARIF consumer.
+type sarifReporter struct {
+	mu       sync.Mutex
+	findings []Finding
+}
+
+func (s *sarifReporter) Report(f Finding) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.findings = append(s.findings, f)
+}
+
+func (s *sarifReporter) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rules := sarifRules(s.findings)
+	results := make([]sarifResult, 0, len(s.findings))
+	for _, f := range s.findings {
+		results = append(results, sarifResult{
+			RuleID:  f.Rule,
+			Message: sarifMessage{Text: fmt.Sprintf("%s: %s", f.Repo, f.SecretPreview)},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: f.File},
+					Region:           sarifRegion{StartLine: f.Line},
+				},
+			}},
+			PartialFingerprints: map[string]string{
+				"gitexhume/blobAndRule/v1": fingerprint(f),
+			},
+		})
+	}
+
+	report := sarifReport{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:  "gitexhume",
+				Rules: rules,
+			}},
+			Results: results,
+		}},
+	}
+
+	json.NewEncoder(os.Stdout).Encode(report)
+}
+
+// fingerprint keys a SARIF partialFingerprint by (blob hash, rule ID), so
+// the same secret blob reported under the same rule across different
+// commits or branches collapses to one fingerprint.
+func fingerprint(f Finding) string {
+	return f.BlobHash + "|" + f.Rule
+}
+
+// sarifRules collects a deduplicated, sorted reportingDescriptor per rule
+// ID referenced by findings.
+func sarifRules(findings []Finding) []sarifRule {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, f := range findings {
+		if !seen[f.Rule] {
+			seen[f.Rule] = true
+			ids = append(ids, f.Rule)
+		}
+	}
+	sort.Strings(ids)
+
+	rules := make([]sarifRule, 0, len(ids))
+	for _, id := range ids {
+		rules = append(rules, sarifRule{ID: id})
+	}
+	return rules
+}
+
+// maskSecret redacts all but a short prefix of a secret, leaving enough to
+// recognize the credential without reproducing it in full.
+func maskSecret(s string) string {
+	if len(s) <= 4 {
+		return strings.Repeat("*", len(s))
+	}
+	return s[:4] + strings.Repeat("*", len(s)-4)
+}
+
+// SARIF 2.1.0 document shape, trimmed to the fields gitexhume populates.
+type sarifReport struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
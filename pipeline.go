@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+)
+
+// cloneResult is handed from a clone worker to a scan worker once a
+// repository has been fetched to disk.
+type cloneResult struct {
+	repo Repo
+	dir  string
+}
+
+// storeAndScanRepos clones (or, on a repeat run, fetches) repos into
+// OutputDir and scans them for words, overlapping the two phases with a
+// pair of worker pools of size jobs: as soon as a clone worker finishes a
+// repo it is handed to a scan worker over a channel, rather than waiting
+// for every repo to be cloned first. Per-repo clone errors are collected
+// and reported in a summary at the end instead of aborting the whole run.
+func storeAndScanRepos(repos []Repo, OutputDir string, username string, words []string, rules *RuleSet, reporter Reporter, snap *Snapshot, fullRescan bool, jobs int) {
+	if err := os.MkdirAll(OutputDir, os.ModePerm); err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating %s directory: %s\n", OutputDir, err)
+		os.Exit(1)
+	}
+
+	total := len(repos)
+	reposCh := make(chan Repo)
+	scanCh := make(chan cloneResult)
+
+	var cloned int32
+	var mu sync.Mutex
+	var errs []error
+
+	var cloneWG sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		cloneWG.Add(1)
+		go func() {
+			defer cloneWG.Done()
+			for r := range reposCh {
+				dir, err := cloneRepo(r, OutputDir, username)
+				n := atomic.AddInt32(&cloned, 1)
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("%s: %w", r.Name, err))
+					mu.Unlock()
+					fmt.Fprintf(os.Stderr, "[%d/%d] failed to clone %s: %v\n", n, total, r.Name, err)
+					continue
+				}
+				fmt.Fprintf(os.Stderr, "[%d/%d] cloned %s\n", n, total, r.Name)
+				scanCh <- cloneResult{repo: r, dir: dir}
+			}
+		}()
+	}
+
+	var scanWG sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		scanWG.Add(1)
+		go func() {
+			defer scanWG.Done()
+			for res := range scanCh {
+				scanRepo(res.repo, res.dir, words, rules, reporter, snap, fullRescan)
+			}
+		}()
+	}
+
+	go func() {
+		for _, r := range repos {
+			reposCh <- r
+		}
+		close(reposCh)
+	}()
+
+	cloneWG.Wait()
+	close(scanCh)
+	scanWG.Wait()
+
+	if len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "\033[31m%d/%d repositories failed to clone:\033[0m\n", len(errs), total)
+		for _, e := range errs {
+			fmt.Fprintln(os.Stderr, " -", e)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "\033[32mAll %d repositories cloned and scanned successfully\033[0m\n", total)
+}
+
+// cloneRepo fetches a single repository into OutputDir: if it was cloned by
+// an earlier run it is updated with "git fetch --all" instead of being
+// re-cloned from scratch, otherwise it's bare-cloned with a partial clone
+// (--filter=blob:none) since only history, not working-tree blobs, is
+// needed for the scan phase.
+func cloneRepo(r Repo, OutputDir string, username string) (string, error) {
+	targetDir := fmt.Sprintf("%s/%s", OutputDir, r.Name)
+
+	if _, err := os.Stat(targetDir); err == nil {
+		cmd := exec.Command("git", "--git-dir", targetDir, "fetch", "--all")
+		cmd.Stdout = nil
+		cmd.Stderr = nil
+		if err := cmd.Run(); err != nil {
+			return "", err
+		}
+		return targetDir, nil
+	}
+
+	repoURL := r.CloneURL
+	if repoURL == "" {
+		repoURL = fmt.Sprintf("https://github.com/%s/%s.git", username, r.Name)
+	}
+
+	cmd := exec.Command("git", "clone", "--bare", "--filter=blob:none", repoURL, targetDir)
+	cmd.Stdout = nil
+	cmd.Stderr = nil
+
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	return targetDir, nil
+}
+
+// searchRepos scans the full commit history of each already-cloned
+// repository for the configured keywords, distributing repos across jobs
+// concurrent scan workers.
+func searchRepos(repos []Repo, OutputDir string, words []string, rules *RuleSet, reporter Reporter, snap *Snapshot, fullRescan bool, jobs int) {
+	reposCh := make(chan Repo)
+
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range reposCh {
+				repoDir := fmt.Sprintf("%s/%s", OutputDir, r.Name)
+				scanRepo(r, repoDir, words, rules, reporter, snap, fullRescan)
+			}
+		}()
+	}
+
+	for _, r := range repos {
+		reposCh <- r
+	}
+	close(reposCh)
+
+	wg.Wait()
+}
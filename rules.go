@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Allowlist suppresses matches whose capture group or file path matches one
+// of Regexes/Paths, mirroring Gitleaks' per-rule allowlist block.
+type Allowlist struct {
+	Regexes []string `toml:"regexes" yaml:"regexes"`
+	Paths   []string `toml:"paths" yaml:"paths"`
+
+	regexes []*regexp.Regexp
+	paths   []*regexp.Regexp
+}
+
+// Rule is a single secret-detection rule in the Gitleaks config schema: a
+// regex to match, an optional file-path filter, an optional Shannon-entropy
+// floor for the matched group, and an allowlist to suppress false positives.
+type Rule struct {
+	ID        string    `toml:"id" yaml:"id"`
+	Regex     string    `toml:"regex" yaml:"regex"`
+	Path      string    `toml:"path" yaml:"path"`
+	Entropy   float64   `toml:"entropy" yaml:"entropy"`
+	Allowlist Allowlist `toml:"allowlist" yaml:"allowlist"`
+
+	re     *regexp.Regexp
+	pathRe *regexp.Regexp
+}
+
+// RuleSet is the top-level document shape of a Gitleaks-style rule file.
+type RuleSet struct {
+	Rules []Rule `toml:"rules" yaml:"rules"`
+}
+
+// defaultRules is the built-in ruleset used when -rules is not given, so
+// gitexhume finds common credential shapes out of the box.
+func defaultRules() *RuleSet {
+	return &RuleSet{Rules: []Rule{
+		{ID: "aws-access-key", Regex: `(AKIA[0-9A-Z]{16})`},
+		{ID: "gcp-api-key", Regex: `(AIza[0-9A-Za-z\-_]{35})`},
+		{ID: "slack-token", Regex: `(xox[baprs]-[0-9A-Za-z-]{10,64})`},
+		{ID: "private-key-header", Regex: `(-----BEGIN [A-Z ]*PRIVATE KEY-----)`},
+		{ID: "generic-password", Regex: `(?i)password\s*[:=]\s*['"]?([a-zA-Z0-9!@#$%^&*()_+\-=]{8,})`, Entropy: 3.5},
+	}}
+}
+
+// loadRules reads a Gitleaks-compatible rule file, picking a TOML or YAML
+// decoder based on the file extension, then compiles every rule's regexes.
+func loadRules(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rs RuleSet
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &rs)
+	case ".toml":
+		err = toml.Unmarshal(data, &rs)
+	default:
+		return nil, fmt.Errorf("unsupported rules file extension: %s", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rs.compile(); err != nil {
+		return nil, err
+	}
+
+	return &rs, nil
+}
+
+// compile pre-compiles every rule's and allowlist's regexes so matching a
+// blob doesn't re-parse them on every call.
+func (rs *RuleSet) compile() error {
+	for i := range rs.Rules {
+		if err := rs.Rules[i].compile(); err != nil {
+			return fmt.Errorf("rule %q: %w", rs.Rules[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) compile() error {
+	re, err := regexp.Compile(r.Regex)
+	if err != nil {
+		return err
+	}
+	r.re = re
+
+	if r.Path != "" {
+		pathRe, err := regexp.Compile(r.Path)
+		if err != nil {
+			return err
+		}
+		r.pathRe = pathRe
+	}
+
+	for _, pattern := range r.Allowlist.Regexes {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		r.Allowlist.regexes = append(r.Allowlist.regexes, re)
+	}
+	for _, pattern := range r.Allowlist.Paths {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		r.Allowlist.paths = append(r.Allowlist.paths, re)
+	}
+
+	return nil
+}
+
+// match reports the matched secret text in line if r applies to path and
+// line, after the entropy and allowlist checks. It reports ok=false if the
+// rule doesn't match or the match is suppressed.
+func (r *Rule) match(path, line string) (secret string, ok bool) {
+	if r.pathRe != nil && !r.pathRe.MatchString(path) {
+		return "", false
+	}
+
+	groups := r.re.FindStringSubmatch(line)
+	if groups == nil {
+		return "", false
+	}
+
+	secret = groups[0]
+	if len(groups) > 1 {
+		secret = groups[1]
+	}
+
+	if r.Entropy > 0 && shannonEntropy(secret) < r.Entropy {
+		return "", false
+	}
+
+	for _, re := range r.Allowlist.regexes {
+		if re.MatchString(line) {
+			return "", false
+		}
+	}
+	for _, re := range r.Allowlist.paths {
+		if re.MatchString(path) {
+			return "", false
+		}
+	}
+
+	return secret, true
+}
+
+// shannonEntropy computes the Shannon entropy, in bits per byte, of s.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+
+	entropy := 0.0
+	length := float64(len(s))
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / length
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy
+}
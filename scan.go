@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// scanRepo walks the commit history of an already-cloned repository
+// in-process with go-git, matching every blob's contents against the
+// compiled wordlist pattern and the configured secret-detection rules.
+// Matches are deduped by (blob hash, matched line) so a blob shared across
+// thousands of commits is only scanned and reported once. Unless
+// fullRescan is set, commits and blobs already recorded in snap are
+// skipped, so repeat runs only scan what's new since the last one.
+func scanRepo(r Repo, repoDir string, words []string, rules *RuleSet, reporter Reporter, snap *Snapshot, fullRescan bool) {
+	pattern, err := regexp.Compile(strings.Join(words, "|"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[%s] invalid pattern: %v\n", r.Name, err)
+		return
+	}
+
+	repo, err := git.PlainOpen(repoDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[%s] open error: %v\n", r.Name, err)
+		return
+	}
+
+	commits, err := repo.Log(&git.LogOptions{All: true})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[%s] log error: %v\n", r.Name, err)
+		return
+	}
+
+	state := snap.state(r.Name)
+	seen := make(map[string]bool) // key: blobHash|rule|line, reset per repo
+
+	err = commits.ForEach(func(c *object.Commit) error {
+		commitHash := c.Hash.String()
+		if !fullRescan && state.hasCommit(commitHash) {
+			return nil // already scanned in a previous run
+		}
+
+		tree, err := c.Tree()
+		if err != nil {
+			return nil // skip malformed commit, keep walking
+		}
+
+		walker := object.NewTreeWalker(tree, true, nil)
+		defer walker.Close()
+
+		for {
+			name, entry, err := walker.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				break
+			}
+			if !entry.Mode.IsFile() {
+				continue
+			}
+
+			blobHash := entry.Hash.String()
+			rulePathKey := blobHash + "|" + name
+
+			// The wordlist is path-independent, so once a blob has been
+			// checked against it anywhere it never needs rechecking. Rules
+			// can have a path filter, though, so the same blob content
+			// reappearing under a path not seen before must still be
+			// evaluated against every rule.
+			checkWordlist := fullRescan || !state.hasBlob(blobHash)
+			checkRules := fullRescan || !state.hasRulePath(rulePathKey)
+			if !checkWordlist && !checkRules {
+				continue
+			}
+
+			blob, err := repo.BlobObject(entry.Hash)
+			if err != nil {
+				continue
+			}
+
+			scanBlob(r, c, name, blob, pattern, rules, seen, reporter, checkWordlist, checkRules)
+			state.markBlob(blobHash)
+			state.markRulePath(rulePathKey)
+		}
+
+		state.markCommit(commitHash)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[%s] scan error: %v\n", r.Name, err)
+	}
+
+	if err := snap.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "[%s] snapshot save error: %v\n", r.Name, err)
+	}
+}
+
+// scanBlob matches a single blob's contents, line by line, against the
+// wordlist pattern and every secret-detection rule, reporting any new
+// (hash, rule, line) match found while walking commit c. checkWordlist and
+// checkRules let the caller skip either half of the evaluation when the
+// snapshot shows it was already done for this blob (wordlist) or this
+// specific (blob, path) pair (rules).
+func scanBlob(r Repo, c *object.Commit, path string, blob *object.Blob, pattern *regexp.Regexp, rules *RuleSet, seen map[string]bool, reporter Reporter, checkWordlist, checkRules bool) {
+	reader, err := blob.Reader()
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return
+	}
+
+	hash := blob.Hash.String()
+	for i, line := range strings.Split(string(data), "\n") {
+		if checkWordlist && pattern.MatchString(line) {
+			reportOnce(seen, hash, "wordlist", line, func() {
+				reporter.Report(newFinding(r, c, path, i+1, "wordlist", line, hash))
+			})
+		}
+
+		if !checkRules || rules == nil {
+			continue
+		}
+		for _, rule := range rules.Rules {
+			secret, ok := rule.match(path, line)
+			if !ok {
+				continue
+			}
+			reportOnce(seen, hash, rule.ID, line, func() {
+				reporter.Report(newFinding(r, c, path, i+1, rule.ID, secret, hash))
+			})
+		}
+	}
+}
+
+// reportOnce invokes report if (hash, source, line) hasn't been reported
+// before for this repo, then marks it seen.
+func reportOnce(seen map[string]bool, hash, source, line string, report func()) {
+	key := hash + "|" + source + "|" + line
+	if seen[key] {
+		return
+	}
+	seen[key] = true
+	report()
+}
+
+// newFinding assembles a Finding from the commit and match being reported.
+func newFinding(r Repo, c *object.Commit, path string, line int, rule, match, blobHash string) Finding {
+	return Finding{
+		Repo:          r.Name,
+		Commit:        c.Hash.String(),
+		Author:        c.Author.Name,
+		Date:          c.Author.When,
+		File:          path,
+		Line:          line,
+		Rule:          rule,
+		Match:         match,
+		SecretPreview: maskSecret(match),
+		BlobHash:      blobHash,
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// snapshotFile is the name of the on-disk record of already-scanned
+// commits and blobs, kept alongside the cloned repos in OutputDir.
+const snapshotFile = ".gitexhume-state.json"
+
+// RepoState records the commit and blob SHAs already scanned for one
+// repository, so a later run can skip re-scanning them. Blobs tracks which
+// blob hashes have been checked against the (path-independent) wordlist.
+// RulePaths tracks which (blob hash, path) pairs have been checked against
+// the rule set, since a rule's path filter means the same blob content can
+// still need evaluating again under a path it hasn't been seen at before.
+// A worker pool scans every repo concurrently, so all access goes through
+// the has*/mark* methods, which hold mu for the duration of the map
+// operation.
+type RepoState struct {
+	Commits   map[string]bool `json:"commits"`
+	Blobs     map[string]bool `json:"blobs"`
+	RulePaths map[string]bool `json:"rule_paths"`
+
+	mu sync.Mutex
+}
+
+func newRepoState() *RepoState {
+	return &RepoState{
+		Commits:   make(map[string]bool),
+		Blobs:     make(map[string]bool),
+		RulePaths: make(map[string]bool),
+	}
+}
+
+func (st *RepoState) hasCommit(hash string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.Commits[hash]
+}
+
+func (st *RepoState) markCommit(hash string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.Commits[hash] = true
+}
+
+func (st *RepoState) hasBlob(hash string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.Blobs[hash]
+}
+
+func (st *RepoState) markBlob(hash string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.Blobs[hash] = true
+}
+
+func (st *RepoState) hasRulePath(key string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.RulePaths[key]
+}
+
+func (st *RepoState) markRulePath(key string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.RulePaths[key] = true
+}
+
+// copy returns a RepoState holding a point-in-time copy of st's maps, safe
+// to hand to a JSON encoder without holding any lock for the duration of
+// the encode.
+func (st *RepoState) copy() *RepoState {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	c := &RepoState{
+		Commits:   make(map[string]bool, len(st.Commits)),
+		Blobs:     make(map[string]bool, len(st.Blobs)),
+		RulePaths: make(map[string]bool, len(st.RulePaths)),
+	}
+	for k := range st.Commits {
+		c.Commits[k] = true
+	}
+	for k := range st.Blobs {
+		c.Blobs[k] = true
+	}
+	for k := range st.RulePaths {
+		c.RulePaths[k] = true
+	}
+	return c
+}
+
+// Snapshot is the persisted record of already-scanned history across every
+// repository, keyed by repo name. It mirrors the incremental sync pattern
+// used for repeated cron-style scans: a fetch instead of a re-clone, and a
+// scan of only what's new since the last run.
+type Snapshot struct {
+	Repos map[string]*RepoState `json:"repos"`
+
+	mu   sync.Mutex
+	path string
+}
+
+// loadSnapshot reads the snapshot file from OutputDir, returning an empty
+// Snapshot if none exists yet.
+func loadSnapshot(outputDir string) (*Snapshot, error) {
+	snap := &Snapshot{Repos: make(map[string]*RepoState), path: filepath.Join(outputDir, snapshotFile)}
+
+	data, err := os.ReadFile(snap.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return snap, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, snap); err != nil {
+		return nil, err
+	}
+	if snap.Repos == nil {
+		snap.Repos = make(map[string]*RepoState)
+	}
+	for _, st := range snap.Repos {
+		if st.Commits == nil {
+			st.Commits = make(map[string]bool)
+		}
+		if st.Blobs == nil {
+			st.Blobs = make(map[string]bool)
+		}
+		if st.RulePaths == nil {
+			st.RulePaths = make(map[string]bool)
+		}
+	}
+
+	return snap, nil
+}
+
+// save persists the snapshot to its path in OutputDir. It takes a
+// point-in-time copy of every repo's state before encoding, so a
+// concurrent scanRepo call mutating its own RepoState's maps never races
+// with the JSON encoder here.
+func (s *Snapshot) save() error {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.Repos))
+	for name := range s.Repos {
+		names = append(names, name)
+	}
+	states := make(map[string]*RepoState, len(names))
+	for _, name := range names {
+		states[name] = s.Repos[name]
+	}
+	s.mu.Unlock()
+
+	snapshotCopy := &Snapshot{Repos: make(map[string]*RepoState, len(states))}
+	for name, st := range states {
+		snapshotCopy.Repos[name] = st.copy()
+	}
+
+	data, err := json.MarshalIndent(snapshotCopy, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// state returns the RepoState for repoName, creating one if this is the
+// first time the repo has been scanned.
+func (s *Snapshot) state(repoName string) *RepoState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.Repos[repoName]
+	if !ok {
+		st = newRepoState()
+		s.Repos[repoName] = st
+	}
+	return st
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestSnapshotStateCreatesAndReuses(t *testing.T) {
+	snap, err := loadSnapshot(t.TempDir())
+	if err != nil {
+		t.Fatalf("loadSnapshot: %v", err)
+	}
+
+	st := snap.state("repo-a")
+	st.markCommit("c1")
+	st.markBlob("b1")
+	st.markRulePath("b1|path")
+
+	again := snap.state("repo-a")
+	if !again.hasCommit("c1") || !again.hasBlob("b1") || !again.hasRulePath("b1|path") {
+		t.Fatalf("state() did not return the same RepoState for repeat calls")
+	}
+
+	other := snap.state("repo-b")
+	if other.hasCommit("c1") {
+		t.Fatalf("a different repo's state should not share marks")
+	}
+}
+
+func TestSnapshotSaveAndReload(t *testing.T) {
+	dir := t.TempDir()
+	snap, err := loadSnapshot(dir)
+	if err != nil {
+		t.Fatalf("loadSnapshot: %v", err)
+	}
+
+	st := snap.state("repo-a")
+	st.markCommit("c1")
+	st.markBlob("b1")
+	st.markRulePath("b1|path")
+
+	if err := snap.save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, snapshotFile)); err != nil {
+		t.Fatalf("snapshot file not written: %v", err)
+	}
+
+	reloaded, err := loadSnapshot(dir)
+	if err != nil {
+		t.Fatalf("loadSnapshot (reload): %v", err)
+	}
+	rst := reloaded.state("repo-a")
+	if !rst.hasCommit("c1") || !rst.hasBlob("b1") || !rst.hasRulePath("b1|path") {
+		t.Fatalf("reloaded snapshot is missing marks written before save")
+	}
+}
+
+func TestSnapshotSaveConcurrentWithMutation(t *testing.T) {
+	snap, err := loadSnapshot(t.TempDir())
+	if err != nil {
+		t.Fatalf("loadSnapshot: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"repo-a", "repo-b"} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			st := snap.state(name)
+			for i := 0; i < 200; i++ {
+				st.markCommit(name)
+				st.markBlob(name)
+				st.markRulePath(name)
+			}
+		}(name)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			if err := snap.save(); err != nil {
+				t.Errorf("save: %v", err)
+			}
+		}
+	}()
+
+	wg.Wait()
+}